@@ -10,37 +10,88 @@ import (
 )
 
 // Decode decodes an QuiteOk (qoi) image.Image from an io.Reader.
-// It first uses DecodeConfig to decode the image config and then decodes the pixel data.
+// It reads the header then decodes the pixel data, so r ends up positioned
+// right after the EOF marker. Decode is registered with image.RegisterFormat,
+// so image.Decode also recognizes QOI streams.
+//
+// If the header reports Channels == 3, the source had no alpha channel; Decode
+// returns an *image.RGBA with alpha forced to 255 rather than decoding it per
+// pixel.
 func Decode(r io.Reader) (image.Image, error) {
-	conf, err := DecodeConfig(r)
+	h, err := DecodeHeader(r)
 	if err != nil {
 		return nil, err
 	}
-	return decodePixels(r, conf)
+	return decodePixels(r, h)
 }
 
 // DecodeConfig decodes an QuiteOk (qoi) image.Config from an io.Reader.
-// The advanced io.Reader cannot be used to then Decode the image.Image.
+// DecodeConfig is registered with image.RegisterFormat, so image.DecodeConfig
+// also recognizes QOI streams.
 func DecodeConfig(r io.Reader) (image.Config, error) {
-	// read the header bytes
-	buf := make([]byte, 14)
-	if _, err := io.ReadAtLeast(r, buf, len(buf)); err != nil {
+	h, err := DecodeHeader(r)
+	if err != nil {
 		return image.Config{}, err
 	}
-	// validate the magic bytes
+	return image.Config{
+		Width:      h.Width,
+		Height:     h.Height,
+		ColorModel: h.colorModel(),
+	}, nil
+}
+
+// Header is the parsed form of a QOI file's 14-byte header.
+type Header struct {
+	Width, Height int
+	// Channels is 3 (RGB, no alpha channel in the source) or 4 (RGBA).
+	Channels uint8
+	// Colorspace is 0 (sRGB with linear alpha) or 1 (all channels linear).
+	Colorspace uint8
+}
+
+// colorModel returns the color.Model Decode/DecodeConfig expose for h.
+func (h Header) colorModel() color.Model {
+	if h.Channels == 3 {
+		return color.RGBAModel
+	}
+	return color.NRGBAModel
+}
+
+// DecodeHeader reads and validates the 14-byte QOI header from r. Decode and
+// DecodeConfig both parse it through DecodeHeader, so the two stay in sync
+// and either can be used on its own (e.g. by image.RegisterFormat, which
+// calls them on independent reads of the reader).
+func DecodeHeader(r io.Reader) (Header, error) {
+	buf := make([]byte, 14)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return Header{}, err
+	}
 	if string(buf[:4]) != Magic {
-		return image.Config{}, fmt.Errorf("%w: expected %q, actual %q", ErrInvalidMagic, Magic, string(buf[:4]))
+		return Header{}, fmt.Errorf("%w: expected %q, actual %q", ErrInvalidMagic, Magic, string(buf[:4]))
 	}
-	// read the width and height (ignores `channels` and `colorspace`)
-	return image.Config{
+	h := Header{
 		Width:      int(binary.BigEndian.Uint32(buf[4:8])),
 		Height:     int(binary.BigEndian.Uint32(buf[8:12])),
-		ColorModel: color.NRGBAModel,
-	}, nil
+		Channels:   buf[12],
+		Colorspace: buf[13],
+	}
+	if h.Channels != 3 && h.Channels != 4 {
+		return Header{}, fmt.Errorf("%w: must be 3 or 4, actual %d", ErrInvalidChannels, h.Channels)
+	}
+	if h.Colorspace != 0 && h.Colorspace != 1 {
+		return Header{}, fmt.Errorf("%w: must be 0 or 1, actual %d", ErrInvalidColorspace, h.Colorspace)
+	}
+	if h.Width <= 0 || h.Height <= 0 {
+		return Header{}, fmt.Errorf("%w: width and height must be positive, actual %dx%d", ErrInvalidDimensions, h.Width, h.Height)
+	}
+	if h.Width > maxPixels/h.Height {
+		return Header{}, fmt.Errorf("%w: %dx%d exceeds the %d pixel limit", ErrInvalidDimensions, h.Width, h.Height, maxPixels)
+	}
+	return h, nil
 }
 
-func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
-	img := image.NewNRGBA(image.Rect(0, 0, conf.Width, conf.Height))
+func decodePixels(r io.Reader, h Header) (image.Image, error) {
+	img := image.NewNRGBA(image.Rect(0, 0, h.Width, h.Height))
 
 	last := &startPixel
 	buf := make([]byte, 8)
@@ -50,8 +101,9 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 		seen[i] = &zeroPixel
 	}
 
-	for y := 0; y < conf.Height; y++ {
-		for x := 0; x < conf.Width; x++ {
+	total := h.Width * h.Height
+	for y := 0; y < h.Height; y++ {
+		for x := 0; x < h.Width; x++ {
 			off := img.PixOffset(x, y)
 			pix := (*[4]uint8)(img.Pix[off : off+4 : off+4])
 			// handle other run iterations
@@ -64,13 +116,13 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 				continue
 			}
 			// decode new pixel
-			if _, err := r.Read(buf[:1]); err != nil {
-				return nil, err
+			if _, err := io.ReadFull(r, buf[:1]); err != nil {
+				return nil, fmt.Errorf("%w at pixel (%d,%d)", err, x, y)
 			}
 			switch {
 			case buf[0] == opRgb:
-				if _, err := r.Read(buf[1:4]); err != nil {
-					return nil, err
+				if _, err := io.ReadFull(r, buf[1:4]); err != nil {
+					return nil, fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, y, buf[0])
 				}
 				pix[0] = buf[1]
 				pix[1] = buf[2]
@@ -79,8 +131,8 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 				seen[hashPix(pix)] = pix
 				last = pix
 			case buf[0] == opRgba:
-				if _, err := r.Read(buf[1:5]); err != nil {
-					return nil, err
+				if _, err := io.ReadFull(r, buf[1:5]); err != nil {
+					return nil, fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, y, buf[0])
 				}
 				pix[0] = buf[1]
 				pix[1] = buf[2]
@@ -103,8 +155,8 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 				seen[hashPix(pix)] = pix
 				last = pix
 			case buf[0]&op2Mask == opLuma:
-				if _, err := r.Read(buf[1:2]); err != nil {
-					return nil, err
+				if _, err := io.ReadFull(r, buf[1:2]); err != nil {
+					return nil, fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, y, buf[0])
 				}
 				dg := (buf[0] & 0b00111111) - 32
 				dr := (buf[1]&0b11110000)>>4 - 8 + dg
@@ -118,7 +170,10 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 			case buf[0]&op2Mask == opRun:
 				run = buf[0]&0b00111111 + 1
 				if run > 62 || run < 1 {
-					return nil, fmt.Errorf("%w: must be between 1 and 62, actual %d", ErrInvalidRunLength, run)
+					return nil, fmt.Errorf("%w at pixel (%d,%d) op=0x%02x: must be between 1 and 62, actual %d", ErrInvalidRunLength, x, y, buf[0], run)
+				}
+				if remaining := total - (y*h.Width + x); int(run) > remaining {
+					return nil, fmt.Errorf("%w at pixel (%d,%d) op=0x%02x: run of %d pixels overruns the %d remaining pixels", ErrInvalidRunLength, x, y, buf[0], run, remaining)
 				}
 				// first run iteration
 				run -= 1
@@ -131,12 +186,22 @@ func decodePixels(r io.Reader, conf image.Config) (image.Image, error) {
 	}
 
 	// check EOF sequence
-	if _, err := r.Read(buf); err != nil {
-		return nil, err
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, fmt.Errorf("%w at pixel (%d,%d)", err, h.Width, h.Height)
 	}
 	if !bytes.Equal(buf, eof[:]) {
 		return nil, fmt.Errorf("%w: expected %b, actual %b", ErrInvalidEOF, eof, buf)
 	}
 
+	if h.Channels == 3 {
+		// The source had no alpha channel: force it opaque instead of taking a
+		// per-pixel decode path, and hand back the bytes as an *image.RGBA
+		// (identical to NRGBA once alpha is always 255, premultiplied or not).
+		for i := 3; i < len(img.Pix); i += 4 {
+			img.Pix[i] = 0xff
+		}
+		return &image.RGBA{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect}, nil
+	}
+
 	return img, nil
 }
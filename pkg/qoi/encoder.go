@@ -0,0 +1,181 @@
+package qoi
+
+import (
+	"encoding/binary"
+	"image"
+	"image/color"
+	"io"
+)
+
+// Options configures Encode. The zero value writes a 4-channel, sRGB-with-linear-alpha
+// header, which is what every other QOI encoder produces by default.
+type Options struct {
+	// Channels is the value written to the header's channels byte: 3 (RGB) or 4 (RGBA).
+	// Zero defaults to 4. If Channels is 3, every pixel is encoded as fully opaque
+	// (alpha forced to 255) regardless of the source image's actual alpha, matching
+	// what Decode does with a channels==3 header on the way back in.
+	Channels uint8
+	// Colorspace is the value written to the header's colorspace byte: 0 (sRGB w/ linear
+	// alpha) or 1 (all linear). It is purely informational and does not affect encoding.
+	Colorspace uint8
+}
+
+// Encode writes m to w in the QuiteOk (qoi) format using the default Options.
+func Encode(w io.Writer, m image.Image) error {
+	return Options{}.Encode(w, m)
+}
+
+// Encode writes m to w in the QuiteOk (qoi) format, filling the header from o.
+func (o Options) Encode(w io.Writer, m image.Image) error {
+	channels := o.Channels
+	if channels == 0 {
+		channels = 4
+	}
+
+	b := m.Bounds()
+	width, height := b.Dx(), b.Dy()
+
+	header := make([]byte, 14)
+	copy(header[:4], Magic)
+	binary.BigEndian.PutUint32(header[4:8], uint32(width))
+	binary.BigEndian.PutUint32(header[8:12], uint32(height))
+	header[12] = channels
+	header[13] = o.Colorspace
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	at := pixelSource(m)
+
+	last := startPixel
+	seen := [64][4]uint8{}
+	run := 0
+	buf := make([]byte, 5)
+
+	flushRun := func() error {
+		if run == 0 {
+			return nil
+		}
+		buf[0] = opRun | byte(run-1)
+		run = 0
+		_, err := w.Write(buf[:1])
+		return err
+	}
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			pix := at(x, y)
+			if channels == 3 {
+				pix[3] = 0xff
+			}
+			if pix == last {
+				run++
+				if run == 62 {
+					if err := flushRun(); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+			if err := flushRun(); err != nil {
+				return err
+			}
+
+			idx := hashPix(&pix)
+			if seen[idx] == pix {
+				buf[0] = opIndex | idx
+				if _, err := w.Write(buf[:1]); err != nil {
+					return err
+				}
+				last = pix
+				continue
+			}
+			seen[idx] = pix
+
+			if pix[3] != last[3] {
+				buf[0], buf[1], buf[2], buf[3], buf[4] = opRgba, pix[0], pix[1], pix[2], pix[3]
+				if _, err := w.Write(buf[:5]); err != nil {
+					return err
+				}
+				last = pix
+				continue
+			}
+
+			dr := int8(pix[0] - last[0])
+			dg := int8(pix[1] - last[1])
+			db := int8(pix[2] - last[2])
+			drg := dr - dg
+			dbg := db - dg
+
+			switch {
+			case dr >= -2 && dr <= 1 && dg >= -2 && dg <= 1 && db >= -2 && db <= 1:
+				buf[0] = opDiff | byte(dr+2)<<4 | byte(dg+2)<<2 | byte(db+2)
+				if _, err := w.Write(buf[:1]); err != nil {
+					return err
+				}
+			case dg >= -32 && dg <= 31 && drg >= -8 && drg <= 7 && dbg >= -8 && dbg <= 7:
+				buf[0] = opLuma | byte(dg+32)
+				buf[1] = byte(drg+8)<<4 | byte(dbg+8)
+				if _, err := w.Write(buf[:2]); err != nil {
+					return err
+				}
+			default:
+				buf[0], buf[1], buf[2], buf[3] = opRgb, pix[0], pix[1], pix[2]
+				if _, err := w.Write(buf[:4]); err != nil {
+					return err
+				}
+			}
+			last = pix
+		}
+	}
+	if err := flushRun(); err != nil {
+		return err
+	}
+
+	_, err := w.Write(eof[:])
+	return err
+}
+
+// pixelSource returns a function yielding the straight-alpha NRGBA pixel at (x, y),
+// where (0, 0) is m.Bounds().Min. It fast-paths *image.NRGBA and *image.RGBA by
+// walking Pix directly instead of going through the image.Image/color.Color
+// interfaces for every pixel.
+func pixelSource(m image.Image) func(x, y int) [4]uint8 {
+	b := m.Bounds()
+	switch src := m.(type) {
+	case *image.NRGBA:
+		return func(x, y int) [4]uint8 {
+			off := src.PixOffset(b.Min.X+x, b.Min.Y+y)
+			pix := src.Pix[off : off+4 : off+4]
+			return [4]uint8{pix[0], pix[1], pix[2], pix[3]}
+		}
+	case *image.RGBA:
+		return func(x, y int) [4]uint8 {
+			off := src.PixOffset(b.Min.X+x, b.Min.Y+y)
+			pix := src.Pix[off : off+4 : off+4]
+			r, g, bl := unpremultiply(pix[0], pix[1], pix[2], pix[3])
+			return [4]uint8{r, g, bl, pix[3]}
+		}
+	default:
+		return func(x, y int) [4]uint8 {
+			c := color.NRGBAModel.Convert(m.At(b.Min.X+x, b.Min.Y+y)).(color.NRGBA)
+			return [4]uint8{c.R, c.G, c.B, c.A}
+		}
+	}
+}
+
+// unpremultiply converts an alpha-premultiplied RGB triple back to straight alpha,
+// mirroring the arithmetic color.NRGBAModel uses for color.RGBA.
+func unpremultiply(r, g, b, a uint8) (uint8, uint8, uint8) {
+	if a == 0xff {
+		return r, g, b
+	}
+	if a == 0 {
+		return 0, 0, 0
+	}
+	a32 := uint32(a) * 0x101
+	r32 := uint32(r) * 0x101 * 0xffff / a32
+	g32 := uint32(g) * 0x101 * 0xffff / a32
+	b32 := uint32(b) * 0x101 * 0xffff / a32
+	return uint8(r32 >> 8), uint8(g32 >> 8), uint8(b32 >> 8)
+}
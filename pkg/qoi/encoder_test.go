@@ -0,0 +1,168 @@
+package qoi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestEncodeDecodeRoundTrip checks that Decode(Encode(img)) reproduces img
+// exactly for a variety of NRGBA images, each chosen to push the encoder
+// down a different chunk op (RUN, DIFF, LUMA, RGB, RGBA, INDEX).
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	tests := map[string]*image.NRGBA{
+		"solid (RUN)":          solidNRGBA(4, 4, color.NRGBA{R: 10, G: 20, B: 30, A: 255}),
+		"gradient (DIFF/LUMA)": gradientNRGBA(8, 8),
+		"random (RGB/RGBA)":    randomNRGBA(6, 6),
+		"repeating (INDEX)":    repeatingNRGBA(6, 6),
+	}
+
+	for name, want := range tests {
+		t.Run(name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := Encode(&buf, want); err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			got, err := Decode(&buf)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+			gotNRGBA, ok := got.(*image.NRGBA)
+			if !ok {
+				t.Fatalf("Decode returned %T, want *image.NRGBA", got)
+			}
+			if gotNRGBA.Rect != want.Rect || gotNRGBA.Stride != want.Stride {
+				t.Fatalf("geometry mismatch: got %+v/%d, want %+v/%d", gotNRGBA.Rect, gotNRGBA.Stride, want.Rect, want.Stride)
+			}
+			if !bytes.Equal(gotNRGBA.Pix, want.Pix) {
+				t.Fatalf("round-tripped pixels differ from source")
+			}
+		})
+	}
+}
+
+// TestEncodeRGBASourceUnpremultiplies checks the *image.RGBA fast path in
+// pixelSource: encoding a premultiplied-alpha source and decoding the result
+// back must recover the original straight-alpha values, the same way
+// color.NRGBAModel.Convert would.
+func TestEncodeRGBASourceUnpremultiplies(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 2, 2))
+	colors := [4]color.RGBA{
+		{R: 10, G: 20, B: 30, A: 40},
+		{R: 128, G: 64, B: 32, A: 128},
+		{R: 255, G: 0, B: 0, A: 255},
+		{R: 0, G: 0, B: 0, A: 0},
+	}
+	src.SetRGBA(0, 0, colors[0])
+	src.SetRGBA(1, 0, colors[1])
+	src.SetRGBA(0, 1, colors[2])
+	src.SetRGBA(1, 1, colors[3])
+
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	gotNRGBA, ok := got.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Decode returned %T, want *image.NRGBA", got)
+	}
+
+	want := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	for i, c := range colors {
+		want.SetNRGBA(i%2, i/2, color.NRGBAModel.Convert(c).(color.NRGBA))
+	}
+	if !bytes.Equal(gotNRGBA.Pix, want.Pix) {
+		t.Fatalf("round-tripped pixels = %v, want %v", gotNRGBA.Pix, want.Pix)
+	}
+}
+
+// TestEncodeOptionsChannels3ForcesOpaque checks that encoding with
+// Options{Channels: 3} never carries real alpha into the bitstream: decoding
+// the result comes back fully opaque, matching what a channels==3 header
+// promises regardless of what alpha the source image actually had.
+func TestEncodeOptionsChannels3ForcesOpaque(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 2, 2))
+	src.SetNRGBA(0, 0, color.NRGBA{R: 10, G: 20, B: 30, A: 128})
+	src.SetNRGBA(1, 0, color.NRGBA{R: 40, G: 50, B: 60, A: 200})
+	src.SetNRGBA(0, 1, color.NRGBA{R: 70, G: 80, B: 90, A: 0})
+	src.SetNRGBA(1, 1, color.NRGBA{R: 100, G: 110, B: 120, A: 255})
+
+	var buf bytes.Buffer
+	if err := (Options{Channels: 3}).Encode(&buf, src); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	got, err := Decode(&buf)
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	rgba, ok := got.(*image.RGBA)
+	if !ok {
+		t.Fatalf("Decode returned %T, want *image.RGBA", got)
+	}
+	for i := 3; i < len(rgba.Pix); i += 4 {
+		if rgba.Pix[i] != 0xff {
+			t.Fatalf("pixel alpha at byte %d = %d, want 255", i, rgba.Pix[i])
+		}
+	}
+}
+
+func solidNRGBA(w, h int, c color.NRGBA) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func gradientNRGBA(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * x),
+				G: uint8(y * 30),
+				B: uint8((x + y) % 3),
+				A: 255,
+			})
+		}
+	}
+	img.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 10, B: 90, A: 128})
+	return img
+}
+
+func randomNRGBA(w, h int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	seed := uint32(0x12345678)
+	next := func() uint8 {
+		seed = seed*1664525 + 1013904223
+		return uint8(seed >> 24)
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{R: next(), G: next(), B: next(), A: next()})
+		}
+	}
+	return img
+}
+
+func repeatingNRGBA(w, h int) *image.NRGBA {
+	palette := []color.NRGBA{
+		{R: 10, G: 20, B: 30, A: 255},
+		{R: 40, G: 50, B: 60, A: 255},
+		{R: 70, G: 80, B: 90, A: 255},
+	}
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.SetNRGBA(x, y, palette[(x+y)%len(palette)])
+		}
+	}
+	return img
+}
@@ -0,0 +1,21 @@
+package qoi
+
+import "errors"
+
+// Sentinel errors returned by Decode, DecodeConfig and the pixel decoder.
+var (
+	// ErrInvalidMagic is returned when a stream does not start with Magic.
+	ErrInvalidMagic = errors.New("qoi: invalid magic")
+	// ErrInvalidRunLength is returned when a QOI_OP_RUN chunk encodes a run length outside [1, 62]
+	// or a run that extends past the image's last pixel.
+	ErrInvalidRunLength = errors.New("qoi: invalid run length")
+	// ErrInvalidEOF is returned when a stream does not end with the 8-byte EOF marker.
+	ErrInvalidEOF = errors.New("qoi: invalid eof marker")
+	// ErrInvalidChannels is returned when a header's channels byte is not 3 or 4.
+	ErrInvalidChannels = errors.New("qoi: invalid channels")
+	// ErrInvalidColorspace is returned when a header's colorspace byte is not 0 or 1.
+	ErrInvalidColorspace = errors.New("qoi: invalid colorspace")
+	// ErrInvalidDimensions is returned when a header's width/height are non-positive
+	// or so large that allocating the image would be unreasonable.
+	ErrInvalidDimensions = errors.New("qoi: invalid dimensions")
+)
@@ -0,0 +1,69 @@
+package qoi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// mustEncode encodes m into a QOI byte stream, panicking on error. Encode
+// can only fail if the underlying io.Writer does, and bytes.Buffer never
+// errors, so this is safe to use to build fixtures.
+func mustEncode(m image.Image) []byte {
+	return mustEncodeOptions(Options{}, m)
+}
+
+func mustEncodeOptions(o Options, m image.Image) []byte {
+	var buf bytes.Buffer
+	if err := o.Encode(&buf, m); err != nil {
+		panic(err)
+	}
+	return buf.Bytes()
+}
+
+// FuzzDecode exercises Decode with arbitrary bytes. It never expects a
+// particular result, only that Decode doesn't panic and doesn't return a
+// nil image alongside a nil error.
+func FuzzDecode(f *testing.F) {
+	// A solid-color image: after the first DIFF chunk, every remaining
+	// pixel is a single RUN chunk.
+	solid := image.NewNRGBA(image.Rect(0, 0, 4, 4))
+	for i := range solid.Pix {
+		solid.Pix[i] = 0xff
+	}
+	f.Add(mustEncode(solid))
+
+	// A gradient exercising DIFF, LUMA, RGB and RGBA chunks: per-pixel
+	// steps that grow along each row, a large jump at each row start, and
+	// one translucent pixel.
+	grad := image.NewNRGBA(image.Rect(0, 0, 8, 8))
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			grad.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * x),
+				G: uint8(y * 30),
+				B: uint8((x + y) % 3),
+				A: 255,
+			})
+		}
+	}
+	grad.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 10, B: 90, A: 128})
+	f.Add(mustEncode(grad))
+
+	// A 3-channel image, to exercise the opaque *image.RGBA decode path.
+	f.Add(mustEncodeOptions(Options{Channels: 3}, solid))
+
+	f.Add([]byte{})
+	f.Add([]byte(Magic))
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		img, err := Decode(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		if img == nil {
+			t.Fatal("Decode returned a nil image with a nil error")
+		}
+	})
+}
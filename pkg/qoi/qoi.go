@@ -0,0 +1,38 @@
+package qoi
+
+// Magic is the 4-byte magic sequence that every QOI stream starts with.
+const Magic = "qoif"
+
+// Chunk tags, as laid out by the QOI specification. opIndex, opDiff, opLuma and
+// opRun only occupy the top two bits of the tag byte (see op2Mask); opRgb and
+// opRgba occupy all eight.
+const (
+	opIndex byte = 0x00 // 0b00xxxxxx
+	opDiff  byte = 0x40 // 0b01xxxxxx
+	opLuma  byte = 0x80 // 0b10xxxxxx
+	opRun   byte = 0xc0 // 0b11xxxxxx
+	opRgb   byte = 0xfe // 0b11111110
+	opRgba  byte = 0xff // 0b11111111
+)
+
+// op2Mask isolates the 2-bit tag shared by the INDEX, DIFF, LUMA and RUN ops.
+const op2Mask byte = 0xc0
+
+// maxPixels bounds width*height so a malformed or hostile header can't force
+// an unreasonably large allocation before a single byte of pixel data has
+// been read. It matches the reference QOI implementation's own guard.
+const maxPixels = 400_000_000
+
+// eof is the 8-byte marker that terminates every QOI stream.
+var eof = [8]byte{0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}
+
+// startPixel is the pixel value decoding/encoding starts from before any chunk is applied.
+var startPixel = [4]uint8{0, 0, 0, 255}
+
+// zeroPixel seeds every slot of the running array ("seen") before it has been written to.
+var zeroPixel = [4]uint8{0, 0, 0, 0}
+
+// hashPix computes the running-array index for pix, as defined by the QOI specification.
+func hashPix(pix *[4]uint8) byte {
+	return (pix[0]*3 + pix[1]*5 + pix[2]*7 + pix[3]*11) % 64
+}
@@ -0,0 +1,7 @@
+package qoi
+
+import "image"
+
+func init() {
+	image.RegisterFormat("qoi", Magic, Decode, DecodeConfig)
+}
@@ -0,0 +1,51 @@
+package qoi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// TestRegisterFormat checks that registering QOI with the image package (see
+// init in register.go) lets image.Decode and image.DecodeConfig sniff and
+// dispatch QOI streams without the caller naming the qoi package directly.
+func TestRegisterFormat(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 3, 3))
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 3; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{R: uint8(x * 50), G: uint8(y * 50), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := Encode(&buf, src); err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	data := buf.Bytes()
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("image.DecodeConfig: %v", err)
+	}
+	if format != "qoi" {
+		t.Fatalf("format = %q, want %q", format, "qoi")
+	}
+	if cfg.Width != 3 || cfg.Height != 3 {
+		t.Fatalf("config = %+v, want 3x3", cfg)
+	}
+
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("image.Decode: %v", err)
+	}
+	if format != "qoi" {
+		t.Fatalf("format = %q, want %q", format, "qoi")
+	}
+	got, ok := img.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("image.Decode returned %T, want *image.NRGBA", img)
+	}
+	if !bytes.Equal(got.Pix, src.Pix) {
+		t.Fatalf("round-tripped pixels differ from source")
+	}
+}
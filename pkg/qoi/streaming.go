@@ -0,0 +1,155 @@
+package qoi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+)
+
+// Decoder incrementally decodes a QOI stream one scanline at a time instead
+// of materializing the whole image up front. This suits very large images,
+// or readers where holding the full decoded frame in memory isn't practical
+// (piping over a network, feeding a resizer row by row, computing a hash of
+// the pixels without ever allocating them all).
+//
+// Unlike decodePixels, which aliases last/seen directly into the backing
+// *image.NRGBA, Decoder keeps its own copies of that state: the caller's dst
+// buffer for one scanline is not guaranteed to live past the ReadScanline
+// call that fills it.
+type Decoder struct {
+	r   io.Reader
+	buf []byte
+
+	// Header is the parsed header of the stream being decoded.
+	Header Header
+
+	last [4]uint8
+	seen [64][4]uint8
+	run  byte
+
+	y    int
+	done bool
+}
+
+// NewDecoder parses the QOI header from r and returns a Decoder ready to
+// stream scanlines via ReadScanline.
+func NewDecoder(r io.Reader) (*Decoder, error) {
+	h, err := DecodeHeader(r)
+	if err != nil {
+		return nil, err
+	}
+	return &Decoder{
+		r:      r,
+		buf:    make([]byte, 8),
+		Header: h,
+		last:   startPixel,
+	}, nil
+}
+
+// ScanlineSize is the number of bytes one call to ReadScanline writes: one
+// NRGBA pixel (4 bytes) per column.
+func (d *Decoder) ScanlineSize() int {
+	return d.Header.Width * 4
+}
+
+// ReadScanline decodes the next row of the image into dst as tightly packed
+// NRGBA bytes; len(dst) must be at least d.ScanlineSize(). Once every row and
+// the trailing EOF marker have been consumed, ReadScanline returns io.EOF.
+//
+// If d.Header.Channels == 3, the source had no alpha channel; ReadScanline
+// forces every pixel in dst opaque (alpha 0xff) rather than handing back
+// whatever alpha byte an OP_RGBA chunk happened to carry, matching Decode.
+func (d *Decoder) ReadScanline(dst []uint8) error {
+	if d.done {
+		return io.EOF
+	}
+	if want := d.ScanlineSize(); len(dst) < want {
+		return fmt.Errorf("qoi: ReadScanline: dst has %d bytes, want at least %d", len(dst), want)
+	}
+
+	for x := 0; x < d.Header.Width; x++ {
+		pix := (*[4]uint8)(dst[x*4 : x*4+4 : x*4+4])
+		if err := d.decodePixel(pix, x); err != nil {
+			return err
+		}
+	}
+
+	if d.Header.Channels == 3 {
+		for i := 3; i < d.ScanlineSize(); i += 4 {
+			dst[i] = 0xff
+		}
+	}
+
+	d.y++
+	if d.y == d.Header.Height {
+		if _, err := io.ReadFull(d.r, d.buf); err != nil {
+			return fmt.Errorf("%w at pixel (%d,%d)", err, d.Header.Width, d.Header.Height)
+		}
+		if !bytes.Equal(d.buf, eof[:]) {
+			return fmt.Errorf("%w: expected %b, actual %b", ErrInvalidEOF, eof, d.buf)
+		}
+		d.done = true
+	}
+	return nil
+}
+
+// decodePixel decodes the pixel at column x of the current row into pix,
+// advancing (and persisting) the decoder's running state: last pixel, seen
+// array and pending run count.
+func (d *Decoder) decodePixel(pix *[4]uint8, x int) error {
+	if d.run > 0 {
+		d.run--
+		*pix = d.last
+		return nil
+	}
+
+	if _, err := io.ReadFull(d.r, d.buf[:1]); err != nil {
+		return fmt.Errorf("%w at pixel (%d,%d)", err, x, d.y)
+	}
+	switch {
+	case d.buf[0] == opRgb:
+		if _, err := io.ReadFull(d.r, d.buf[1:4]); err != nil {
+			return fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, d.y, d.buf[0])
+		}
+		pix[0], pix[1], pix[2], pix[3] = d.buf[1], d.buf[2], d.buf[3], d.last[3]
+		d.seen[hashPix(pix)] = *pix
+	case d.buf[0] == opRgba:
+		if _, err := io.ReadFull(d.r, d.buf[1:5]); err != nil {
+			return fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, d.y, d.buf[0])
+		}
+		pix[0], pix[1], pix[2], pix[3] = d.buf[1], d.buf[2], d.buf[3], d.buf[4]
+		d.seen[hashPix(pix)] = *pix
+	case d.buf[0]&op2Mask == opIndex:
+		*pix = d.seen[d.buf[0]]
+	case d.buf[0]&op2Mask == opDiff:
+		pix[0] = d.last[0] + (d.buf[0]>>4)&0x3 - 2
+		pix[1] = d.last[1] + (d.buf[0]>>2)&0x3 - 2
+		pix[2] = d.last[2] + (d.buf[0]>>0)&0x3 - 2
+		pix[3] = d.last[3]
+		d.seen[hashPix(pix)] = *pix
+	case d.buf[0]&op2Mask == opLuma:
+		if _, err := io.ReadFull(d.r, d.buf[1:2]); err != nil {
+			return fmt.Errorf("%w at pixel (%d,%d) op=0x%02x", err, x, d.y, d.buf[0])
+		}
+		dg := (d.buf[0] & 0b00111111) - 32
+		dr := (d.buf[1]&0b11110000)>>4 - 8 + dg
+		db := (d.buf[1]&0b00001111)>>0 - 8 + dg
+		pix[0] = d.last[0] + dr
+		pix[1] = d.last[1] + dg
+		pix[2] = d.last[2] + db
+		pix[3] = d.last[3]
+		d.seen[hashPix(pix)] = *pix
+	case d.buf[0]&op2Mask == opRun:
+		d.run = d.buf[0]&0b00111111 + 1
+		if d.run > 62 || d.run < 1 {
+			return fmt.Errorf("%w at pixel (%d,%d) op=0x%02x: must be between 1 and 62, actual %d", ErrInvalidRunLength, x, d.y, d.buf[0], d.run)
+		}
+		if remaining := d.Header.Width*d.Header.Height - (d.y*d.Header.Width + x); int(d.run) > remaining {
+			return fmt.Errorf("%w at pixel (%d,%d) op=0x%02x: run of %d pixels overruns the %d remaining pixels", ErrInvalidRunLength, x, d.y, d.buf[0], d.run, remaining)
+		}
+		d.run--
+		*pix = d.last
+	}
+	d.last = *pix
+	return nil
+}
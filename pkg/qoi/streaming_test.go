@@ -0,0 +1,100 @@
+package qoi
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"io"
+	"testing"
+)
+
+// TestDecoderMatchesDecode checks that scanline-by-scanline output from
+// Decoder matches Decode's full-image output byte for byte, and that
+// ReadScanline's state (last/seen/run) actually persists across calls by
+// exercising RUN, DIFF, LUMA, RGB, RGBA and INDEX chunks within and across
+// rows.
+func TestDecoderMatchesDecode(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 8, 5))
+	for y := 0; y < 5; y++ {
+		for x := 0; x < 8; x++ {
+			src.SetNRGBA(x, y, color.NRGBA{
+				R: uint8(x * x),
+				G: uint8(y * 20),
+				B: uint8((x + y) % 4),
+				A: 255,
+			})
+		}
+	}
+	// A run spanning a scanline boundary, and a partially transparent pixel
+	// to force an RGBA chunk.
+	for x := 2; x < 8; x++ {
+		src.SetNRGBA(x, 1, color.NRGBA{R: 10, G: 10, B: 10, A: 255})
+	}
+	for x := 0; x < 3; x++ {
+		src.SetNRGBA(x, 2, color.NRGBA{R: 10, G: 10, B: 10, A: 255})
+	}
+	src.SetNRGBA(5, 3, color.NRGBA{R: 1, G: 2, B: 3, A: 100})
+	// A repeat of the run color rows later, to force an INDEX chunk that
+	// reads the seen array filled in an earlier scanline.
+	src.SetNRGBA(1, 4, color.NRGBA{R: 10, G: 10, B: 10, A: 255})
+
+	data := mustEncode(src)
+
+	full, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+	want, ok := full.(*image.NRGBA)
+	if !ok {
+		t.Fatalf("Decode returned %T, want *image.NRGBA", full)
+	}
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	if d.Header.Width != 8 || d.Header.Height != 5 {
+		t.Fatalf("Header = %+v, want 8x5", d.Header)
+	}
+
+	got := make([]byte, 0, len(want.Pix))
+	row := make([]byte, d.ScanlineSize())
+	for {
+		if err := d.ReadScanline(row); err != nil {
+			if err == io.EOF {
+				break
+			}
+			t.Fatalf("ReadScanline: %v", err)
+		}
+		got = append(got, row...)
+	}
+
+	if !bytes.Equal(got, want.Pix) {
+		t.Fatalf("streamed pixels differ from Decode's output")
+	}
+	if err := d.ReadScanline(row); err != io.EOF {
+		t.Fatalf("ReadScanline after exhaustion = %v, want io.EOF", err)
+	}
+}
+
+// TestDecoderChannels3ForcesOpaque checks that ReadScanline forces alpha to
+// 0xff for a channels==3 header, matching Decode, even when the bitstream
+// itself carries a non-255 alpha byte (which a malformed or hostile encoder
+// could still produce).
+func TestDecoderChannels3ForcesOpaque(t *testing.T) {
+	header := []byte{'q', 'o', 'i', 'f', 0, 0, 0, 1, 0, 0, 0, 1, 3, 0}
+	pixel := []byte{opRgba, 10, 20, 30, 55}
+	data := append(append(header, pixel...), eof[:]...)
+
+	d, err := NewDecoder(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("NewDecoder: %v", err)
+	}
+	row := make([]byte, d.ScanlineSize())
+	if err := d.ReadScanline(row); err != nil {
+		t.Fatalf("ReadScanline: %v", err)
+	}
+	if row[3] != 0xff {
+		t.Fatalf("alpha = %d, want 255", row[3])
+	}
+}